@@ -0,0 +1,136 @@
+// Package assets copies a template's static assets into the output
+// directory, optionally fingerprinting each file's name with a hash of
+// its contents so it can be cached aggressively by a CDN.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetsDir is where a template's static assets live, relative to the
+// template's own root.
+const assetsDir = "pages/assets"
+
+// Options tunes how assets are copied, driven by a template's
+// config.yml `assets:` block.
+type Options struct {
+	// Hash fingerprints each file's output name with a hash of its
+	// contents for cache-busting. Defaults to true.
+	Hash bool
+	// Exclude lists glob patterns (matched against the asset's path
+	// relative to assets/) to skip entirely.
+	Exclude []string
+	// PublicPath is prepended to every URL returned in the manifest, e.g.
+	// a CDN origin.
+	PublicPath string
+}
+
+// Manifest maps a logical asset path (its path relative to assets/, e.g.
+// "css/site.css") to the URL it was published under.
+type Manifest map[string]string
+
+// Copy walks assetsDir inside templateFS, writes each file into
+// outputDir/assets (fingerprinted if Hash is set), and writes the
+// resulting Manifest to outputDir/assets/manifest.json.
+func Copy(templateFS fs.FS, outputDir string, opts Options) (Manifest, error) {
+	manifest := Manifest{}
+
+	if _, err := fs.Stat(templateFS, assetsDir); err != nil {
+		return manifest, nil
+	}
+
+	err := fs.WalkDir(templateFS, assetsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(assetsDir, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if isExcluded(relPath, opts.Exclude) {
+			return nil
+		}
+
+		data, err := fs.ReadFile(templateFS, p)
+		if err != nil {
+			return fmt.Errorf("error reading asset %s: %v", p, err)
+		}
+
+		outputName := relPath
+		if opts.Hash {
+			outputName = fingerprint(relPath, data)
+		}
+
+		dstPath := filepath.Join(outputDir, "assets", filepath.FromSlash(outputName))
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dstPath, data, 0644); err != nil {
+			return err
+		}
+
+		manifest[relPath] = joinPublicPath(opts.PublicPath, path.Join("assets", outputName))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error copying assets: %v", err)
+	}
+
+	if err := writeManifest(outputDir, manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func fingerprint(relPath string, data []byte) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])[:10]
+
+	ext := path.Ext(relPath)
+	base := strings.TrimSuffix(relPath, ext)
+	return fmt.Sprintf("%s.%s%s", base, hash, ext)
+}
+
+func isExcluded(relPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPublicPath(publicPath, assetPath string) string {
+	if publicPath == "" {
+		return "/" + assetPath
+	}
+	return strings.TrimRight(publicPath, "/") + "/" + assetPath
+}
+
+func writeManifest(outputDir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding asset manifest: %v", err)
+	}
+
+	manifestPath := filepath.Join(outputDir, "assets", "manifest.json")
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, data, 0644)
+}
@@ -0,0 +1,64 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCopyFingerprintsWhenEnabled(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"pages/assets/css/site.css": {Data: []byte("body { color: red; }")},
+	}
+	outputDir := t.TempDir()
+
+	manifest, err := Copy(templateFS, outputDir, Options{Hash: true})
+	if err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+
+	hashed, ok := manifest["css/site.css"]
+	if !ok {
+		t.Fatalf("expected manifest entry for css/site.css, got %v", manifest)
+	}
+	if hashed == "/assets/css/site.css" {
+		t.Errorf("expected hashed URL, got unhashed %q", hashed)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(hashed))); err != nil {
+		t.Errorf("expected hashed asset to be written at %s: %v", hashed, err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "assets", "manifest.json")); err != nil {
+		t.Errorf("expected assets/manifest.json to be written: %v", err)
+	}
+}
+
+func TestCopyHonorsExcludeAndPublicPath(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"pages/assets/css/site.css":     {Data: []byte("body {}")},
+		"pages/assets/css/site.css.map": {Data: []byte("{}")},
+	}
+	outputDir := t.TempDir()
+
+	manifest, err := Copy(templateFS, outputDir, Options{
+		Hash:       false,
+		Exclude:    []string{"css/*.map"},
+		PublicPath: "https://cdn.example.com",
+	})
+	if err != nil {
+		t.Fatalf("Copy returned error: %v", err)
+	}
+
+	if _, ok := manifest["css/site.css.map"]; ok {
+		t.Errorf("expected css/site.css.map to be excluded, got manifest %v", manifest)
+	}
+
+	url, ok := manifest["css/site.css"]
+	if !ok {
+		t.Fatalf("expected manifest entry for css/site.css, got %v", manifest)
+	}
+	if url != "https://cdn.example.com/assets/css/site.css" {
+		t.Errorf("expected public_path-prefixed unhashed URL, got %q", url)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveCollectionItems expands a collection's configured items into the
+// final list generateCollections should render: explicit items with a
+// `source` Markdown file get their front-matter and rendered HTML merged
+// in, and a `source_dir` shortcut synthesizes one item per *.md file found
+// underneath it. Markdown files are read from templateFS rather than the
+// OS filesystem, so paths are relative to the template root and work the
+// same whether the template is a local dir, an embedded FS, or a
+// downloaded one.
+func resolveCollectionItems(templateFS fs.FS, collectionName string, data map[string]interface{}) ([]interface{}, error) {
+	var items []interface{}
+
+	if rawItems, ok := data["items"].([]interface{}); ok {
+		for _, raw := range rawItems {
+			itemMap, ok := raw.(map[string]interface{})
+			if !ok {
+				items = append(items, raw)
+				continue
+			}
+
+			resolved, err := resolveMarkdownItem(templateFS, itemMap)
+			if err != nil {
+				return nil, fmt.Errorf("collection %s: %v", collectionName, err)
+			}
+			items = append(items, resolved)
+		}
+	}
+
+	if sourceDir, ok := data["source_dir"].(string); ok && sourceDir != "" {
+		discovered, err := discoverMarkdownItems(templateFS, sourceDir)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %v", collectionName, err)
+		}
+		items = append(items, discovered...)
+	}
+
+	return items, nil
+}
+
+// resolveMarkdownItem merges a `source: foo.md` item's front-matter into
+// the item map and renders its body into an `html` field. Items without a
+// `source` key are returned unchanged. source is resolved against
+// templateFS, i.e. relative to the template root.
+func resolveMarkdownItem(templateFS fs.FS, item map[string]interface{}) (map[string]interface{}, error) {
+	source, ok := item["source"].(string)
+	if !ok || source == "" {
+		return item, nil
+	}
+
+	raw, err := fs.ReadFile(templateFS, source)
+	if err != nil {
+		return nil, fmt.Errorf("error reading markdown source %s: %v", source, err)
+	}
+
+	return mergeMarkdownFile(raw, item)
+}
+
+// discoverMarkdownItems walks dir (relative to templateFS) for *.md files
+// and synthesizes a collection item for each: front-matter plus a default
+// output_file derived from the file's path relative to dir.
+func discoverMarkdownItems(templateFS fs.FS, dir string) ([]interface{}, error) {
+	var items []interface{}
+
+	err := fs.WalkDir(templateFS, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+
+		raw, err := fs.ReadFile(templateFS, p)
+		if err != nil {
+			return fmt.Errorf("error reading markdown file %s: %v", p, err)
+		}
+
+		relPath := strings.TrimPrefix(p, dir+"/")
+
+		item := map[string]interface{}{
+			"source":      p,
+			"output_file": strings.TrimSuffix(relPath, ".md") + ".html",
+		}
+
+		merged, err := mergeMarkdownFile(raw, item)
+		if err != nil {
+			return fmt.Errorf("error parsing front matter in %s: %v", p, err)
+		}
+
+		items = append(items, merged)
+		return nil
+	})
+
+	return items, err
+}
+
+// mergeMarkdownFile splits front-matter from a Markdown file's contents,
+// merges it under item (item's own keys win on conflict), and renders the
+// remaining body into an "html" field.
+func mergeMarkdownFile(raw []byte, item map[string]interface{}) (map[string]interface{}, error) {
+	front, body, err := splitFrontMatter(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]interface{}{}
+	for k, v := range front {
+		merged[k] = v
+	}
+	for k, v := range item {
+		merged[k] = v
+	}
+	merged["html"] = markdownHelper(body)
+
+	return merged, nil
+}
+
+// splitFrontMatter separates YAML front-matter (fenced by `---` lines)
+// from the Markdown body that follows it. Content with no front-matter
+// fence is returned as-is with a nil front-matter map.
+func splitFrontMatter(raw []byte) (map[string]interface{}, string, error) {
+	content := string(raw)
+	if !strings.HasPrefix(content, "---") {
+		return nil, content, nil
+	}
+
+	parts := strings.SplitN(content, "---", 3)
+	if len(parts) < 3 {
+		return nil, content, nil
+	}
+
+	var front map[string]interface{}
+	if err := yaml.Unmarshal([]byte(parts[1]), &front); err != nil {
+		return nil, "", fmt.Errorf("error parsing front matter: %v", err)
+	}
+
+	return front, strings.TrimPrefix(parts[2], "\n"), nil
+}
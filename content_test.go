@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCollectionsMarkdown(t *testing.T) {
+	config := readConfig("templates/0002/config.yml")
+	outputDir := t.TempDir()
+
+	if err := generateCollections(config, os.DirFS("templates/0002"), outputDir, nil); err != nil {
+		t.Fatalf("generateCollections returned error: %v", err)
+	}
+
+	explicit, err := os.ReadFile(filepath.Join(outputDir, "posts", "hello.html"))
+	if err != nil {
+		t.Fatalf("expected explicit markdown item to be rendered: %v", err)
+	}
+	if !strings.Contains(string(explicit), "Hello World") {
+		t.Errorf("rendered explicit item missing front-matter title, got: %s", explicit)
+	}
+	if !strings.Contains(string(explicit), "<strong>markdown</strong>") {
+		t.Errorf("rendered explicit item missing rendered markdown body, got: %s", explicit)
+	}
+	if !strings.Contains(string(explicit), `<div class="card">Hello World</div>`) {
+		t.Errorf("rendered explicit item missing partials/card.html output, got: %s", explicit)
+	}
+
+	for _, name := range []string{"first-note.html", "second-note.html"} {
+		discovered, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("expected source_dir discovered item %s to be rendered: %v", name, err)
+		}
+		if !strings.Contains(string(discovered), "Note") {
+			t.Errorf("rendered discovered item %s missing front-matter title, got: %s", name, discovered)
+		}
+	}
+}
+
+// TestGenerateCollectionsMarkdownEmbeddedFS runs the same Markdown-backed
+// collection through the compiled-in embeddedTemplates FS instead of
+// os.DirFS, proving source/source_dir resolve with no OS path backing the
+// template at all, as required for air-gapped/embedded use.
+func TestGenerateCollectionsMarkdownEmbeddedFS(t *testing.T) {
+	templateFS, err := fs.Sub(embeddedTemplates, path.Join("templates", "0002"))
+	if err != nil {
+		t.Fatalf("fs.Sub returned error: %v", err)
+	}
+
+	config := readConfig("templates/0002/config.yml")
+	outputDir := t.TempDir()
+
+	if err := generateCollections(config, templateFS, outputDir, nil); err != nil {
+		t.Fatalf("generateCollections returned error: %v", err)
+	}
+
+	explicit, err := os.ReadFile(filepath.Join(outputDir, "posts", "hello.html"))
+	if err != nil {
+		t.Fatalf("expected explicit markdown item to be rendered from the embedded FS: %v", err)
+	}
+	if !strings.Contains(string(explicit), "Hello World") {
+		t.Errorf("rendered explicit item missing front-matter title, got: %s", explicit)
+	}
+
+	discovered, err := os.ReadFile(filepath.Join(outputDir, "first-note.html"))
+	if err != nil {
+		t.Fatalf("expected source_dir discovered item to be rendered from the embedded FS: %v", err)
+	}
+	if !strings.Contains(string(discovered), "Note") {
+		t.Errorf("rendered discovered item missing front-matter title, got: %s", discovered)
+	}
+}
@@ -0,0 +1,237 @@
+// Package feeds generates sitemap.xml and Atom/RSS feeds for a site's
+// collections, driven entirely by data the builder already has on hand
+// once it has rendered a collection's items.
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Site describes the top-level `site:` block in config.yml.
+type Site struct {
+	URL    string
+	Title  string
+	Author string
+}
+
+// Item is the subset of a collection item a feed or sitemap entry needs.
+type Item struct {
+	Title      string
+	Date       string
+	Updated    string
+	Summary    string
+	OutputFile string
+}
+
+// FeedSpec is a collection's `feed:` block.
+type FeedSpec struct {
+	Type   string // "atom" or "rss"
+	Output string
+	Title  string
+	Link   string
+}
+
+// Collection is everything Generate needs about one config.yml collection.
+type Collection struct {
+	Name    string
+	Sitemap bool
+	Feed    *FeedSpec
+	Items   []Item
+}
+
+// Generate writes sitemap.xml (if any collection opts in) and one feed file
+// per collection `feed:` block into outputDir.
+func Generate(site Site, collections []Collection, outputDir string) error {
+	var urls []sitemapURL
+	for _, c := range collections {
+		for _, item := range c.Items {
+			if item.OutputFile == "" {
+				continue
+			}
+			if c.Sitemap {
+				urls = append(urls, sitemapURL{
+					Loc:     joinURL(site.URL, item.OutputFile),
+					LastMod: lastMod(item),
+				})
+			}
+		}
+
+		if c.Feed != nil {
+			if c.Feed.Type != "atom" && c.Feed.Type != "rss" {
+				return fmt.Errorf("collection %s: unknown feed type %q (want atom or rss)", c.Name, c.Feed.Type)
+			}
+			if err := writeXML(filepath.Join(outputDir, c.Feed.Output), feedBody(site, c)); err != nil {
+				return fmt.Errorf("error generating feed for collection %s: %v", c.Name, err)
+			}
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil
+	}
+	return writeSitemap(filepath.Join(outputDir, "sitemap.xml"), urls)
+}
+
+func lastMod(item Item) string {
+	if item.Updated != "" {
+		return item.Updated
+	}
+	return item.Date
+}
+
+// joinURL joins a site's base URL with a page path. It can't use
+// path.Join: that cleans the result and collapses the "//" after a
+// scheme, turning "https://example.com" into "https:/example.com".
+func joinURL(base, p string) string {
+	if base == "" {
+		return p
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(p, "/")
+}
+
+// --- sitemap.xml ---
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+func writeSitemap(outputPath string, urls []sitemapURL) error {
+	set := urlSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: urls}
+	return writeXML(outputPath, set)
+}
+
+// --- Atom / RSS ---
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel rssChan  `xml:"channel"`
+}
+
+type rssChan struct {
+	Title string    `xml:"title"`
+	Link  string    `xml:"link"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+func feedBody(site Site, c Collection) interface{} {
+	link := c.Feed.Link
+	if link == "" {
+		link = site.URL
+	}
+
+	switch c.Feed.Type {
+	case "rss":
+		items := make([]rssItem, 0, len(c.Items))
+		for _, item := range c.Items {
+			items = append(items, rssItem{
+				Title:       item.Title,
+				Link:        joinURL(site.URL, item.OutputFile),
+				Description: item.Summary,
+				PubDate:     rfc1123(item.Date),
+			})
+		}
+		return rssFeed{
+			Version: "2.0",
+			Channel: rssChan{Title: c.Feed.Title, Link: link, Items: items},
+		}
+	default:
+		var author *atomAuthor
+		if site.Author != "" {
+			author = &atomAuthor{Name: site.Author}
+		}
+		entries := make([]atomEntry, 0, len(c.Items))
+		for _, item := range c.Items {
+			entries = append(entries, atomEntry{
+				Title:   item.Title,
+				ID:      joinURL(site.URL, item.OutputFile),
+				Link:    atomLink{Href: joinURL(site.URL, item.OutputFile)},
+				Updated: item.Date,
+				Summary: item.Summary,
+			})
+		}
+		return atomFeed{
+			Xmlns:   "http://www.w3.org/2005/Atom",
+			Title:   c.Feed.Title,
+			ID:      link,
+			Link:    atomLink{Href: link},
+			Updated: time.Now().UTC().Format(time.RFC3339),
+			Author:  author,
+			Entries: entries,
+		}
+	}
+}
+
+func rfc1123(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+func writeXML(outputPath string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,91 @@
+package feeds
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJoinURL(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		p    string
+		want string
+	}{
+		{"scheme preserved", "https://example.com", "posts/hello.html", "https://example.com/posts/hello.html"},
+		{"trailing slash on base", "https://example.com/", "posts/hello.html", "https://example.com/posts/hello.html"},
+		{"leading slash on path", "https://example.com", "/posts/hello.html", "https://example.com/posts/hello.html"},
+		{"empty base returns path unchanged", "", "posts/hello.html", "posts/hello.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := joinURL(tt.base, tt.p)
+			if got != tt.want {
+				t.Errorf("joinURL(%q, %q) = %q, want %q", tt.base, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinURLRoundTripsThroughNetURL(t *testing.T) {
+	got := joinURL("https://example.com", "posts/hello.html")
+
+	parsed, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) returned error: %v", got, err)
+	}
+	if parsed.Scheme != "https" {
+		t.Errorf("expected scheme %q, got %q (full URL: %s)", "https", parsed.Scheme, got)
+	}
+	if parsed.Host != "example.com" {
+		t.Errorf("expected host %q, got %q (full URL: %s)", "example.com", parsed.Host, got)
+	}
+	if parsed.Path != "/posts/hello.html" {
+		t.Errorf("expected path %q, got %q (full URL: %s)", "/posts/hello.html", parsed.Path, got)
+	}
+}
+
+func TestGenerateProducesWellFormedURLs(t *testing.T) {
+	site := Site{URL: "https://example.com", Title: "Example", Author: "Jane Doe"}
+	collections := []Collection{
+		{
+			Name:    "posts",
+			Sitemap: true,
+			Feed:    &FeedSpec{Type: "atom", Output: "feed.xml", Title: "Example Feed", Link: "https://example.com/posts"},
+			Items: []Item{
+				{Title: "Hello World", Date: "2026-01-01T00:00:00Z", Summary: "intro", OutputFile: "posts/hello.html"},
+			},
+		},
+	}
+	outputDir := t.TempDir()
+
+	if err := Generate(site, collections, outputDir); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(outputDir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("expected sitemap.xml to be written: %v", err)
+	}
+	if !strings.Contains(string(sitemap), "<loc>https://example.com/posts/hello.html</loc>") {
+		t.Errorf("sitemap.xml has a malformed <loc>, got: %s", sitemap)
+	}
+
+	feed, err := os.ReadFile(filepath.Join(outputDir, "feed.xml"))
+	if err != nil {
+		t.Fatalf("expected feed.xml to be written: %v", err)
+	}
+	if !strings.Contains(string(feed), `href="https://example.com/posts/hello.html"`) {
+		t.Errorf("feed.xml has a malformed entry link, got: %s", feed)
+	}
+
+	for _, output := range []string{string(sitemap), string(feed)} {
+		if strings.Contains(output, "https:/example.com") {
+			t.Errorf("output still contains a collapsed scheme slash: %s", output)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saksham-ghimire/portfolio-builder/assets"
+	"github.com/yuin/goldmark"
+)
+
+// defaultFuncMap returns every helper the builder knows how to attach to a
+// template, keyed by the name templates reference them under. assetManifest
+// backs the "asset" helper; it may be nil if the build has no assets.
+func defaultFuncMap(assetManifest assets.Manifest) template.FuncMap {
+	return template.FuncMap{
+		"markdown":   markdownHelper,
+		"date":       dateHelper,
+		"dateFormat": dateFormatHelper,
+		"slugify":    slugify,
+		"pascalize":  pascalize,
+		"camelize":   camelize,
+		"dasherize":  dasherize,
+		"pluralize":  pluralize,
+		"json":       jsonHelper,
+		"prettyjson": prettyJSONHelper,
+		"truncate":   truncate,
+		"default":    defaultValue,
+		"htmlSafe":   htmlSafe,
+		"urlJoin":    urlJoin,
+		"asset":      assetHelper(assetManifest),
+	}
+}
+
+// buildFuncMap narrows defaultFuncMap down to the names a template's
+// config.yml opted into via `funcs:`. An empty list means "everything",
+// which keeps existing templates working without a funcs section.
+func buildFuncMap(names []string, assetManifest assets.Manifest) template.FuncMap {
+	all := defaultFuncMap(assetManifest)
+	if len(names) == 0 {
+		return all
+	}
+
+	funcs := template.FuncMap{}
+	for _, name := range names {
+		if fn, ok := all[name]; ok {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+func markdownHelper(src string) template.HTML {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(src), &buf); err != nil {
+		return template.HTML(template.HTMLEscapeString(src))
+	}
+	return template.HTML(buf.String())
+}
+
+func dateHelper(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+func dateFormatHelper(value, layout string) (string, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("dateFormat: %v", err)
+	}
+	return t.Format(layout), nil
+}
+
+var slugifyNonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = slugifyNonAlnum.ReplaceAllString(s, "-")
+	return strings.Trim(s, "-")
+}
+
+func splitWords(s string) []string {
+	s = slugifyNonAlnum.ReplaceAllString(strings.ToLower(s), " ")
+	return strings.Fields(s)
+}
+
+func pascalize(s string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, "")
+}
+
+func camelize(s string) string {
+	p := pascalize(s)
+	if p == "" {
+		return p
+	}
+	return strings.ToLower(p[:1]) + p[1:]
+}
+
+func dasherize(s string) string {
+	return strings.Join(splitWords(s), "-")
+}
+
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	default:
+		return s + "s"
+	}
+}
+
+func jsonHelper(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func prettyJSONHelper(v interface{}) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	if length <= 3 {
+		return s[:length]
+	}
+	return s[:length-3] + "..."
+}
+
+func defaultValue(value, fallback interface{}) interface{} {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+func htmlSafe(s string) template.HTML {
+	return template.HTML(s)
+}
+
+func urlJoin(parts ...string) string {
+	return path.Join(parts...)
+}
+
+// assetHelper returns the `asset "css/site.css"` template func: it looks
+// the logical path up in the manifest assets.Copy produced and falls back
+// to the unhashed /assets/ URL if the manifest has no entry (hashing
+// disabled, or the file wasn't found under assets/).
+func assetHelper(manifest assets.Manifest) func(string) string {
+	return func(logicalPath string) string {
+		if url, ok := manifest[logicalPath]; ok {
+			return url
+		}
+		return "/" + path.Join("assets", logicalPath)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import "testing"
+
+func TestDateFormatHelper(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		layout  string
+		want    string
+		wantErr bool
+	}{
+		{"formats RFC3339 into the given layout", "2026-01-02T00:00:00Z", "2006-01-02", "2026-01-02", false},
+		{"rejects a non-RFC3339 value", "not-a-date", "2006-01-02", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dateFormatHelper(tt.value, tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dateFormatHelper(%q, %q) error = %v, wantErr %v", tt.value, tt.layout, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("dateFormatHelper(%q, %q) = %q, want %q", tt.value, tt.layout, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"post", "posts"},
+		{"bus", "buses"},
+		{"box", "boxes"},
+		{"batch", "batches"},
+		{"dish", "dishes"},
+		{"city", "cities"},
+		{"day", "days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := pluralize(tt.in); got != tt.want {
+				t.Errorf("pluralize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		length int
+		want   string
+	}{
+		{"shorter than length is unchanged", "hello", 10, "hello"},
+		{"longer than length gets an ellipsis", "hello world", 8, "hello..."},
+		{"length at or below the ellipsis has no room for one", "hello world", 3, "hel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncate(tt.s, tt.length); got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFuncMapNarrowsToOptedInNames(t *testing.T) {
+	funcs := buildFuncMap([]string{"slugify", "truncate"}, nil)
+
+	if len(funcs) != 2 {
+		t.Fatalf("expected 2 funcs, got %d: %v", len(funcs), funcNames(funcs))
+	}
+	if _, ok := funcs["slugify"]; !ok {
+		t.Errorf("expected slugify to be included, got %v", funcNames(funcs))
+	}
+	if _, ok := funcs["truncate"]; !ok {
+		t.Errorf("expected truncate to be included, got %v", funcNames(funcs))
+	}
+	if _, ok := funcs["markdown"]; ok {
+		t.Errorf("expected markdown to be excluded, got %v", funcNames(funcs))
+	}
+}
+
+func TestBuildFuncMapReturnsEverythingWhenNamesEmpty(t *testing.T) {
+	funcs := buildFuncMap(nil, nil)
+
+	all := defaultFuncMap(nil)
+	if len(funcs) != len(all) {
+		t.Errorf("expected an empty funcs list to return all %d helpers, got %d: %v", len(all), len(funcs), funcNames(funcs))
+	}
+}
+
+func TestBuildFuncMapIgnoresUnknownNames(t *testing.T) {
+	funcs := buildFuncMap([]string{"slugify", "not-a-real-helper"}, nil)
+
+	if len(funcs) != 1 {
+		t.Errorf("expected unknown names to be silently dropped, got %v", funcNames(funcs))
+	}
+}
+
+func funcNames(funcs map[string]interface{}) []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	return names
+}
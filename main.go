@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/saksham-ghimire/portfolio-builder/assets"
+	"github.com/saksham-ghimire/portfolio-builder/feeds"
 	"github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v3"
 )
@@ -21,6 +26,74 @@ type Config struct {
 	Base        map[string]interface{} `yaml:"base"`
 	Pages       map[string]interface{} `yaml:"pages"`
 	Collections map[string]interface{} `yaml:"collections"`
+	// Funcs lists which template helpers (see funcmap.go) this template
+	// opts into. Leave empty to get the full default set.
+	Funcs []string `yaml:"funcs"`
+	// Site holds the data needed to generate sitemap.xml and feeds; see
+	// feeds.go.
+	Site SiteConfig `yaml:"site"`
+	// Assets tunes the asset pipeline; see assets/assets.go.
+	Assets AssetsConfig `yaml:"assets"`
+}
+
+// AssetsConfig is the top-level `assets:` block.
+type AssetsConfig struct {
+	// Hash fingerprints asset output names with a content hash for
+	// cache-busting. Defaults to true; set false to disable.
+	Hash *bool `yaml:"hash"`
+	// Exclude lists glob patterns (relative to assets/) to skip copying.
+	Exclude []string `yaml:"exclude"`
+	// PublicPath is prepended to every asset URL, e.g. a CDN origin.
+	PublicPath string `yaml:"public_path"`
+}
+
+// toOptions converts the YAML-facing AssetsConfig into assets.Options,
+// applying the Hash-enabled-by-default rule.
+func (c AssetsConfig) toOptions() assets.Options {
+	hash := true
+	if c.Hash != nil {
+		hash = *c.Hash
+	}
+	return assets.Options{
+		Hash:       hash,
+		Exclude:    c.Exclude,
+		PublicPath: c.PublicPath,
+	}
+}
+
+// SiteConfig is the top-level `site:` block, used when building absolute
+// URLs for sitemap.xml and Atom/RSS feeds.
+type SiteConfig struct {
+	URL    string `yaml:"url"`
+	Title  string `yaml:"title"`
+	Author string `yaml:"author"`
+}
+
+// CollectionItem is the typed view of a collection item used wherever
+// fields like date and summary need to round-trip reliably (feeds,
+// sitemaps) instead of being read out of a map[string]interface{}. Pages
+// and collection templates keep receiving the raw map so arbitrary
+// template-specific fields still work.
+type CollectionItem struct {
+	Title      string                 `yaml:"title"`
+	Date       string                 `yaml:"date"`
+	Updated    string                 `yaml:"updated"`
+	Summary    string                 `yaml:"summary"`
+	OutputFile string                 `yaml:"output_file"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+func decodeCollectionItem(raw interface{}) (CollectionItem, error) {
+	b, err := yaml.Marshal(raw)
+	if err != nil {
+		return CollectionItem{}, err
+	}
+
+	var item CollectionItem
+	if err := yaml.Unmarshal(b, &item); err != nil {
+		return CollectionItem{}, err
+	}
+	return item, nil
 }
 
 type GitHubTreeItem struct {
@@ -41,58 +114,95 @@ func main() {
 		fmt.Println("  --template <template-id>  : Downloads the configuration for a specific template. Use this first!")
 		fmt.Println("  --config <file-path>      : (Optional) Specifies the path to your configuration file (default: config.yml).")
 		fmt.Println("  --output-dir <dir-path>   : (Optional) Sets the output directory for the generated site (default: .).")
+		fmt.Println("  --template-dir <dir-path> : (Optional) Use a local template directory instead of the bundled or downloaded one.")
+		fmt.Println("  --serve                   : (Optional) Starts a dev server that rebuilds on change instead of exiting.")
+		fmt.Println("  --addr <host:port>        : (Optional) Address for the dev server to listen on (default: :8080).")
+		fmt.Println("  --watch                   : (Optional) Watch for changes while serving (default: true).")
 		fmt.Println("  --help                    : Shows this help message.")
 		fmt.Println("  --config                    : (Optional) Path to config file (default: .)")
 		fmt.Println("\nExample usage:")
 		fmt.Println("  To download a template configuration: ./portfolio-builder --template=0001")
 		fmt.Println("  To generate your portfolio: ./portfolio-builder")
+		fmt.Println("  To run a dev server with hot reload: ./portfolio-builder --serve")
 	}
 
-	templateId, configFilePath, outputDir := getArgs()
-	configUrl := fmt.Sprintf("https://raw.githubusercontent.com/saksham-ghimire/portfolio-builder/main/templates/%s/config.yml", templateId)
+	args := getArgs()
+	configUrl := fmt.Sprintf("https://raw.githubusercontent.com/saksham-ghimire/portfolio-builder/main/templates/%s/config.yml", args.templateId)
 
-	if templateId != "" {
-		log.Println("Fetching template configuration for id:", templateId)
+	if args.templateId != "" {
+		log.Println("Fetching template configuration for id:", args.templateId)
 		downloadFile(configUrl, "config.yml")
 		log.Println("Successfully fetched the configuration, please update 'config.yml' as needed, and then execute the program without --template to generate your portfolio.")
 		return
 	}
 
-	var config = readConfig(configFilePath)
+	config := readConfig(args.configFile)
 	schemaUrl := fmt.Sprintf("https://raw.githubusercontent.com/saksham-ghimire/portfolio-builder/main/templates/%s/schema.json", config.TemplateId)
-	validateConfig(schemaUrl, config)
-
-	templateDir := downloadTemplateFromGitHub(config.TemplateId)
-	defer os.RemoveAll(templateDir)
-
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		log.Fatalf("Error creating output directory. Received error %v", err)
+	if err := validateConfig(schemaUrl, config); err != nil {
+		log.Fatalf("Config validation failed: %v", err)
 	}
 
-	if err := copyAssets(templateDir+"/pages", outputDir); err != nil {
-		log.Fatalf("Error copying assets. Received error %v", err)
+	templateFS, templateDirPath, cleanup, err := resolveTemplateFS(config.TemplateId, args.templateDir)
+	if err != nil {
+		log.Fatalf("Error resolving template: %v", err)
+	}
+	defer cleanup()
+
+	builder := &Builder{
+		ConfigPath:      args.configFile,
+		SchemaURL:       schemaUrl,
+		TemplateFS:      templateFS,
+		TemplateDirPath: templateDirPath,
+		OutputDir:       args.outputDir,
 	}
 
-	if err := generatePages(config, templateDir+"/pages", outputDir); err != nil {
-		log.Fatalf("Error generating pages. Received error %v", err)
+	ctx := context.Background()
+
+	if args.serve {
+		if err := runServer(ctx, builder, args.addr, args.watch); err != nil {
+			log.Fatalf("Error running dev server: %v", err)
+		}
+		return
 	}
 
-	if err := generateCollections(config, templateDir+"/pages", outputDir); err != nil {
-		log.Fatalf("Error generating collections. Received error %v", err)
+	if err := builder.Build(ctx); err != nil {
+		log.Fatalf("Error building portfolio: %v", err)
 	}
 
 	log.Println("Portfolio generation completed successfully!")
-	log.Printf("Your portfolio is ready in the '%s' directory.", outputDir)
+	log.Printf("Your portfolio is ready in the '%s' directory.", args.outputDir)
 }
 
-func getArgs() (string, string, string) {
+type cliArgs struct {
+	templateId  string
+	configFile  string
+	outputDir   string
+	templateDir string
+	serve       bool
+	addr        string
+	watch       bool
+}
+
+func getArgs() cliArgs {
 	templateId := flag.String("template", "", "Downloads the configuration for a specific template.")
 	configFile := flag.String("config", "config.yml", "Specifies the path to the configuration file.")
 	outputDir := flag.String("output-dir", ".", "Sets the output directory for the generated site.")
+	templateDir := flag.String("template-dir", "", "Use a local template directory instead of the bundled or downloaded one.")
+	serve := flag.Bool("serve", false, "Starts a dev server that rebuilds on change instead of exiting.")
+	addr := flag.String("addr", ":8080", "Address for the dev server to listen on (used with --serve).")
+	watch := flag.Bool("watch", true, "Watch for changes while serving (used with --serve).")
 
 	flag.Parse()
 
-	return *templateId, *configFile, *outputDir
+	return cliArgs{
+		templateId:  *templateId,
+		configFile:  *configFile,
+		outputDir:   *outputDir,
+		templateDir: *templateDir,
+		serve:       *serve,
+		addr:        *addr,
+		watch:       *watch,
+	}
 }
 
 func readConfig(configFile string) Config {
@@ -109,40 +219,42 @@ func readConfig(configFile string) Config {
 	return config
 }
 
-func validateConfig(uri string, config Config) {
+func validateConfig(uri string, config Config) error {
 	schemaLoader := gojsonschema.NewReferenceLoader(uri)
 	jsonBytes, err := json.Marshal(config)
 	if err != nil {
-		log.Fatalf("Error converting config to JSON: %v", err)
+		return fmt.Errorf("error converting config to JSON: %v", err)
 	}
 	documentLoader := gojsonschema.NewBytesLoader(jsonBytes)
 
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 	if err != nil {
-		log.Fatalf("Error validating schema: %v", err)
+		return fmt.Errorf("error validating schema: %v", err)
 	}
 
-	if result.Valid() {
-		log.Println("Config is valid!")
-	} else {
+	if !result.Valid() {
 		for _, desc := range result.Errors() {
 			log.Printf("- %s\n", desc)
 		}
-		log.Fatalf("Config validation failed, please fix the errors")
+		return fmt.Errorf("config validation failed, please fix the errors")
 	}
+
+	log.Println("Config is valid!")
+	return nil
 }
 
-func generatePages(config Config, templateDir, outputDir string) error {
+func generatePages(config Config, templateFS fs.FS, outputDir string, assetManifest assets.Manifest) error {
 	for pageName := range config.Pages {
-		templatePath := filepath.Join(templateDir, pageName+".html")
+		pagePath := path.Join("pages", pageName+".html")
 		var tmpl *template.Template
 		var err error
+		funcs := buildFuncMap(config.Funcs, assetManifest)
+		patterns := partialPatterns(templateFS)
 
 		if config.Base != nil {
-			var basePath = filepath.Join(templateDir, "base.html")
-			tmpl, err = template.ParseFiles(basePath, templatePath)
+			tmpl, err = template.New("base.html").Funcs(funcs).ParseFS(templateFS, append(patterns, "pages/base.html", pagePath)...)
 		} else {
-			tmpl, err = template.ParseFiles(templatePath)
+			tmpl, err = template.New(pageName+".html").Funcs(funcs).ParseFS(templateFS, append(patterns, pagePath)...)
 		}
 
 		if err != nil {
@@ -178,26 +290,34 @@ func generatePages(config Config, templateDir, outputDir string) error {
 	return nil
 }
 
-func generateCollections(config Config, templateDir, outputDir string) error {
+func generateCollections(config Config, templateFS fs.FS, outputDir string, assetManifest assets.Manifest) error {
 	if config.Collections == nil {
 		return nil
 	}
 
 	for collectionName, collectionData := range config.Collections {
-		itemsList, ok := collectionData.(map[string]interface{})["items"].([]interface{})
+		data, ok := collectionData.(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		templatePath := filepath.Join(templateDir, collectionName+".html")
+		itemsList, err := resolveCollectionItems(templateFS, collectionName, data)
+		if err != nil {
+			return err
+		}
+		if len(itemsList) == 0 {
+			continue
+		}
+
+		collectionPath := path.Join("pages", collectionName+".html")
 		var tmpl *template.Template
-		var err error
+		funcs := buildFuncMap(config.Funcs, assetManifest)
+		patterns := partialPatterns(templateFS)
 
 		if config.Base != nil {
-			var basePath = filepath.Join(templateDir, "base.html")
-			tmpl, err = template.ParseFiles(basePath, templatePath)
+			tmpl, err = template.New("base.html").Funcs(funcs).ParseFS(templateFS, append(patterns, "pages/base.html", collectionPath)...)
 		} else {
-			tmpl, err = template.ParseFiles(templatePath)
+			tmpl, err = template.New(collectionName+".html").Funcs(funcs).ParseFS(templateFS, append(patterns, collectionPath)...)
 		}
 
 		if err != nil {
@@ -216,6 +336,10 @@ func generateCollections(config Config, templateDir, outputDir string) error {
 			}
 
 			outputPath := filepath.Join(outputDir, outputFileName)
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return fmt.Errorf("error creating output directory for %s: %v", outputFileName, err)
+			}
+
 			f, err := os.Create(outputPath)
 			if err != nil {
 				return fmt.Errorf("error creating output file %s: %v", outputPath, err)
@@ -245,43 +369,76 @@ func generateCollections(config Config, templateDir, outputDir string) error {
 	return nil
 }
 
-func copyAssets(templateDir, outputDir string) error {
-	assetFolders := []string{"assets"}
-	for _, folder := range assetFolders {
-		srcFolder := filepath.Join(templateDir, folder)
-		if _, err := os.Stat(srcFolder); os.IsNotExist(err) {
+// generateFeeds builds sitemap.xml and any per-collection Atom/RSS feeds
+// declared via `sitemap: true` / `feed:` in config.yml.
+func generateFeeds(config Config, templateFS fs.FS, outputDir string) error {
+	if config.Collections == nil {
+		return nil
+	}
+
+	var collections []feeds.Collection
+	for name, raw := range config.Collections {
+		data, ok := raw.(map[string]interface{})
+		if !ok {
 			continue
 		}
-		dstFolder := filepath.Join(outputDir, folder)
-
-		err := filepath.WalkDir(srcFolder, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			relPath, err := filepath.Rel(srcFolder, path)
-			if err != nil {
-				return err
-			}
 
-			dstPath := filepath.Join(dstFolder, relPath)
+		itemsList, err := resolveCollectionItems(templateFS, name, data)
+		if err != nil {
+			return err
+		}
+		sitemap, _ := data["sitemap"].(bool)
+
+		var feedSpec *feeds.FeedSpec
+		if feedData, ok := data["feed"].(map[string]interface{}); ok {
+			feedType, _ := feedData["type"].(string)
+			feedOutput, _ := feedData["output"].(string)
+			feedTitle, _ := feedData["title"].(string)
+			feedLink, _ := feedData["link"].(string)
+			feedSpec = &feeds.FeedSpec{Type: feedType, Output: feedOutput, Title: feedTitle, Link: feedLink}
+		}
 
-			if d.IsDir() {
-				return os.MkdirAll(dstPath, 0755)
-			}
+		if !sitemap && feedSpec == nil {
+			continue
+		}
 
-			data, err := os.ReadFile(path)
+		var items []feeds.Item
+		for _, raw := range itemsList {
+			item, err := decodeCollectionItem(raw)
 			if err != nil {
-				return err
+				return fmt.Errorf("error decoding item in collection %s: %v", name, err)
 			}
-			return os.WriteFile(dstPath, data, 0644)
+			items = append(items, feeds.Item{
+				Title:      item.Title,
+				Date:       item.Date,
+				Updated:    item.Updated,
+				Summary:    item.Summary,
+				OutputFile: item.OutputFile,
+			})
+		}
+
+		collections = append(collections, feeds.Collection{
+			Name:    name,
+			Sitemap: sitemap,
+			Feed:    feedSpec,
+			Items:   items,
 		})
+	}
 
-		if err == nil {
-			log.Println("Copied folder:", dstFolder)
-		}
+	if len(collections) == 0 {
+		return nil
 	}
 
+	site := feeds.Site{URL: config.Site.URL, Title: config.Site.Title, Author: config.Site.Author}
+	return feeds.Generate(site, collections, outputDir)
+}
+
+// partialPatterns returns the ParseFS glob for a template's pages/partials
+// directory, or nil if the template doesn't have one.
+func partialPatterns(templateFS fs.FS) []string {
+	if info, err := fs.Stat(templateFS, "pages/partials"); err == nil && info.IsDir() {
+		return []string{"pages/partials/*.html"}
+	}
 	return nil
 }
 
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/saksham-ghimire/portfolio-builder/assets"
+)
+
+// Builder runs the build pipeline (validate, copy assets, render pages and
+// collections, generate feeds) that one-shot and --serve mode share.
+type Builder struct {
+	ConfigPath string
+	SchemaURL  string
+	TemplateFS fs.FS
+	// TemplateDirPath is the OS path backing TemplateFS, used by the dev
+	// server to watch for template changes. Empty when TemplateFS is an
+	// embedded (in-binary) template.
+	TemplateDirPath string
+	OutputDir       string
+}
+
+// Build renders the site straight into OutputDir.
+func (b *Builder) Build(ctx context.Context) error {
+	return b.buildInto(ctx, b.OutputDir)
+}
+
+func (b *Builder) buildInto(ctx context.Context, outputDir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	config := readConfig(b.ConfigPath)
+	if err := validateConfig(b.SchemaURL, config); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+	assetManifest, err := assets.Copy(b.TemplateFS, outputDir, config.Assets.toOptions())
+	if err != nil {
+		return fmt.Errorf("error copying assets: %v", err)
+	}
+	if err := generatePages(config, b.TemplateFS, outputDir, assetManifest); err != nil {
+		return fmt.Errorf("error generating pages: %v", err)
+	}
+	if err := generateCollections(config, b.TemplateFS, outputDir, assetManifest); err != nil {
+		return fmt.Errorf("error generating collections: %v", err)
+	}
+	if err := generateFeeds(config, b.TemplateFS, outputDir); err != nil {
+		return fmt.Errorf("error generating sitemap/feeds: %v", err)
+	}
+
+	return nil
+}
+
+// resolvedOutputDir returns OutputDir as an absolute path, rejecting a
+// value that resolves to the process's working directory or to the
+// config/template directory. rebuild swaps OutputDir out from under
+// itself via os.Rename, and renaming any of those directories either
+// fails outright (renaming the process's own cwd out from under itself
+// fails on Linux with "device or resource busy" — the "." default hits
+// this whenever OutputDir resolves to the cwd, regardless of where
+// --config points) or would start watching and rebuilding its own
+// output, so all three are refused up front rather than left to fail
+// rebuild after rebuild.
+func (b *Builder) resolvedOutputDir() (string, error) {
+	outputDir, err := filepath.Abs(b.OutputDir)
+	if err != nil {
+		return "", fmt.Errorf("error resolving output directory: %v", err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error resolving working directory: %v", err)
+	}
+	if outputDir == cwd {
+		return "", fmt.Errorf("--output-dir %q resolves to the process's working directory %q; rebuild can't rename its own cwd mid-swap, use a separate output directory with --serve", b.OutputDir, cwd)
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(b.ConfigPath))
+	if err != nil {
+		return "", fmt.Errorf("error resolving config directory: %v", err)
+	}
+	if outputDir == configDir {
+		return "", fmt.Errorf("--output-dir %q resolves to the config directory %q; use a separate output directory with --serve", b.OutputDir, configDir)
+	}
+
+	if b.TemplateDirPath != "" {
+		templateDir, err := filepath.Abs(b.TemplateDirPath)
+		if err != nil {
+			return "", fmt.Errorf("error resolving template directory: %v", err)
+		}
+		if outputDir == templateDir {
+			return "", fmt.Errorf("--output-dir %q resolves to the template directory %q; use a separate output directory with --serve", b.OutputDir, templateDir)
+		}
+	}
+
+	return outputDir, nil
+}
+
+// rebuild renders into a staging directory next to OutputDir and swaps it
+// in atomically, so requests served from OutputDir never see a partial
+// tree mid-rebuild.
+func (b *Builder) rebuild(ctx context.Context) error {
+	outputDir, err := b.resolvedOutputDir()
+	if err != nil {
+		return err
+	}
+
+	staging := outputDir + ".staging"
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := b.buildInto(ctx, staging); err != nil {
+		return err
+	}
+
+	old := outputDir + ".old"
+	os.RemoveAll(old)
+
+	if _, err := os.Stat(outputDir); err == nil {
+		if err := os.Rename(outputDir, old); err != nil {
+			return fmt.Errorf("error swapping in new build: %v", err)
+		}
+	}
+
+	if err := os.Rename(staging, outputDir); err != nil {
+		os.Rename(old, outputDir)
+		return fmt.Errorf("error swapping in new build: %v", err)
+	}
+
+	os.RemoveAll(old)
+	return nil
+}
+
+// runServer builds the site once, then serves OutputDir over HTTP. With
+// watch enabled it rebuilds on every change to the config file, the
+// template directory, or a collection's source_dir, and long-polling
+// clients reload automatically via the injected livereload script.
+func runServer(ctx context.Context, b *Builder, addr string, watch bool) error {
+	if watch {
+		if _, err := b.resolvedOutputDir(); err != nil {
+			return fmt.Errorf("cannot watch for changes: %v", err)
+		}
+	}
+
+	if err := b.Build(ctx); err != nil {
+		return fmt.Errorf("initial build failed: %v", err)
+	}
+
+	reload := &reloadBroker{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/__livereload", reload.handle)
+	mux.Handle("/", livereloadHandler(b.OutputDir, reload))
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Dev server listening on %s, serving %s", addr, b.OutputDir)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	if watch {
+		go func() {
+			if err := watchAndRebuild(ctx, b, reload); err != nil {
+				log.Printf("file watcher stopped: %v", err)
+			}
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// watchAndRebuild watches the config file's directory, the template
+// directory, and every collection source_dir, debouncing bursts of
+// filesystem events into a single rebuild.
+func watchAndRebuild(ctx context.Context, b *Builder, reload *reloadBroker) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	outputDir, err := b.resolvedOutputDir()
+	if err != nil {
+		return err
+	}
+	excludes := outputDirExcludes(outputDir)
+	if err := addWatchDirs(watcher, watchTargets(b), outputDir); err != nil {
+		return fmt.Errorf("error watching for changes: %v", err)
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !shouldTriggerRebuild(event, excludes) {
+				continue
+			}
+			debounce = time.After(200 * time.Millisecond)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("file watcher error: %v", err)
+
+		case <-debounce:
+			debounce = nil
+			log.Println("Change detected, rebuilding...")
+			if err := b.rebuild(ctx); err != nil {
+				log.Printf("rebuild failed: %v", err)
+				continue
+			}
+			reload.notify()
+		}
+	}
+}
+
+func watchTargets(b *Builder) []string {
+	targets := []string{filepath.Dir(b.ConfigPath)}
+	if b.TemplateDirPath != "" {
+		targets = append(targets, b.TemplateDirPath)
+	}
+
+	config := readConfig(b.ConfigPath)
+	for _, raw := range config.Collections {
+		data, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dir, ok := data["source_dir"].(string); ok && dir != "" {
+			targets = append(targets, dir)
+		}
+	}
+
+	return targets
+}
+
+// addWatchDirs recursively adds dir and every subdirectory under each
+// target to watcher, since fsnotify only watches one level at a time. It
+// skips outputDir and the .staging/.old directories rebuild swaps it
+// with, so a watched target that happens to contain the output dir (e.g.
+// --output-dir dist next to config.yml) doesn't make the server rebuild
+// in response to its own output.
+func addWatchDirs(watcher *fsnotify.Watcher, targets []string, outputDir string) error {
+	excludes := outputDirExcludes(outputDir)
+	for _, target := range targets {
+		err := filepath.WalkDir(target, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if isWithinAny(path, excludes) {
+				return filepath.SkipDir
+			}
+			return watcher.Add(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputDirExcludes returns the absolute paths rebuild writes to and
+// swaps between: outputDir itself, plus the .staging/.old siblings it
+// renames through.
+func outputDirExcludes(outputDir string) []string {
+	if outputDir == "" {
+		return nil
+	}
+	return []string{outputDir, outputDir + ".staging", outputDir + ".old"}
+}
+
+// shouldTriggerRebuild reports whether a filesystem event is a real
+// content change that should trigger a rebuild. fsnotify reports
+// create/rename/remove events for an entry directly inside a watched
+// directory even when that entry itself was excluded from the watch, so
+// rebuild's own dist/dist.staging/dist.old renames would otherwise keep
+// re-triggering themselves whenever the output dir sits inside a watched
+// directory (e.g. --output-dir dist next to config.yml).
+func shouldTriggerRebuild(event fsnotify.Event, excludes []string) bool {
+	if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return false
+	}
+	return !isWithinAny(event.Name, excludes)
+}
+
+// isWithinAny reports whether path is, or is nested under, any of excludes.
+func isWithinAny(path string, excludes []string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, exclude := range excludes {
+		if abs == exclude || strings.HasPrefix(abs, exclude+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadBroker backs the /__livereload long-poll endpoint: clients poll
+// with the version they last saw and block until it changes.
+type reloadBroker struct {
+	mu      sync.Mutex
+	version int
+}
+
+func (r *reloadBroker) notify() {
+	r.mu.Lock()
+	r.version++
+	r.mu.Unlock()
+}
+
+func (r *reloadBroker) current() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version
+}
+
+func (r *reloadBroker) handle(w http.ResponseWriter, req *http.Request) {
+	since, _ := strconv.Atoi(req.URL.Query().Get("since"))
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(30 * time.Second)
+
+	for {
+		if current := r.current(); current != since {
+			fmt.Fprintf(w, "%d", current)
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			fmt.Fprintf(w, "%d", since)
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+const livereloadScript = `<script>
+(function() {
+	var since = 0;
+	function poll() {
+		fetch("/__livereload?since=" + since).then(function(r) { return r.text(); }).then(function(body) {
+			var next = parseInt(body, 10);
+			if (since !== 0 && next !== since) {
+				location.reload();
+				return;
+			}
+			since = next;
+			poll();
+		}).catch(function() {
+			setTimeout(poll, 1000);
+		});
+	}
+	poll();
+})();
+</script>`
+
+// livereloadHandler serves root like http.FileServer, except it injects
+// livereloadScript into HTML responses before </body>.
+func livereloadHandler(root string, reload *reloadBroker) http.Handler {
+	fileServer := http.FileServer(http.Dir(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		localPath := filepath.Join(root, filepath.Clean(r.URL.Path))
+		if strings.HasSuffix(r.URL.Path, "/") {
+			localPath = filepath.Join(localPath, "index.html")
+		}
+
+		if !strings.HasSuffix(localPath, ".html") {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := os.ReadFile(localPath)
+		if err != nil {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		html := string(body)
+		if idx := strings.LastIndex(html, "</body>"); idx != -1 {
+			html = html[:idx] + livereloadScript + html[idx:]
+		} else {
+			html += livereloadScript
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, html)
+	})
+}
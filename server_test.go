@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestRebuildRejectsDefaultDotOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	restore := chdir(t, dir)
+	defer restore()
+
+	// Reproduces the documented default invocation, "./portfolio-builder
+	// --serve": config.yml and the output dir both default to the cwd.
+	b := &Builder{
+		ConfigPath: "config.yml",
+		OutputDir:  ".",
+	}
+
+	if err := b.rebuild(context.Background()); err == nil {
+		t.Fatal("expected rebuild to reject an output dir equal to the config directory")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".staging")); !os.IsNotExist(err) {
+		t.Errorf("rebuild should not have started staging before rejecting the output dir")
+	}
+}
+
+func TestRebuildRejectsDotOutputDirWithConfigElsewhere(t *testing.T) {
+	dir := t.TempDir()
+	restore := chdir(t, dir)
+	defer restore()
+
+	if err := os.Mkdir("subdir", 0755); err != nil {
+		t.Fatalf("os.Mkdir returned error: %v", err)
+	}
+
+	// --config points at a subdirectory but --output-dir keeps its "."
+	// default: configDir != cwd, so a check that only compares against
+	// configDir would miss that OutputDir still resolves to the cwd
+	// rebuild is about to rename out from under itself.
+	b := &Builder{
+		ConfigPath: filepath.Join("subdir", "config.yml"),
+		OutputDir:  ".",
+	}
+
+	if err := b.rebuild(context.Background()); err == nil {
+		t.Fatal("expected rebuild to reject an output dir equal to the process's working directory")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".staging")); !os.IsNotExist(err) {
+		t.Errorf("rebuild should not have started staging before rejecting the output dir")
+	}
+}
+
+func TestRebuildRejectsOutputDirEqualToTemplateDir(t *testing.T) {
+	configDir := t.TempDir()
+	templateDir := t.TempDir()
+
+	b := &Builder{
+		ConfigPath:      filepath.Join(configDir, "config.yml"),
+		TemplateDirPath: templateDir,
+		OutputDir:       templateDir,
+	}
+
+	if err := b.rebuild(context.Background()); err == nil {
+		t.Fatal("expected rebuild to reject an output dir equal to the template directory")
+	}
+}
+
+func TestResolvedOutputDirAllowsSeparateDir(t *testing.T) {
+	configDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "dist")
+
+	b := &Builder{
+		ConfigPath: filepath.Join(configDir, "config.yml"),
+		OutputDir:  outputDir,
+	}
+
+	resolved, err := b.resolvedOutputDir()
+	if err != nil {
+		t.Fatalf("resolvedOutputDir returned error for a distinct output dir: %v", err)
+	}
+
+	want, err := filepath.Abs(outputDir)
+	if err != nil {
+		t.Fatalf("filepath.Abs returned error: %v", err)
+	}
+	if resolved != want {
+		t.Errorf("expected resolved output dir %q, got %q", want, resolved)
+	}
+}
+
+// TestAddWatchDirsExcludesOutputDir reproduces a natural fix-up of the
+// rejected "." default, --output-dir dist next to config.yml: dist sits
+// inside the watched config directory, so the watcher must skip it (and
+// its .staging/.old rebuild siblings) or every successful rebuild would
+// generate fsnotify events that trigger another rebuild forever.
+func TestAddWatchDirsExcludesOutputDir(t *testing.T) {
+	configDir := t.TempDir()
+	outputDir := filepath.Join(configDir, "dist")
+	for _, dir := range []string{outputDir, outputDir + ".staging", outputDir + ".old"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("os.MkdirAll returned error: %v", err)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("fsnotify.NewWatcher returned error: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, []string{configDir}, outputDir); err != nil {
+		t.Fatalf("addWatchDirs returned error: %v", err)
+	}
+
+	for _, watched := range watcher.WatchList() {
+		if isWithinAny(watched, outputDirExcludes(outputDir)) {
+			t.Errorf("expected %s to be excluded from the watch list, got %v", watched, watcher.WatchList())
+		}
+	}
+}
+
+// TestShouldTriggerRebuildIgnoresOwnRebuildEvents reproduces --output-dir
+// dist next to config.yml end to end: fsnotify reports events for a
+// watched directory's immediate children even when that child itself was
+// excluded from the watch, so without event.Name filtering, rebuild's own
+// dist/dist.staging/dist.old renames would re-trigger a rebuild forever.
+func TestShouldTriggerRebuildIgnoresOwnRebuildEvents(t *testing.T) {
+	configDir := t.TempDir()
+	outputDir := filepath.Join(configDir, "dist")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("os.MkdirAll returned error: %v", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatalf("fsnotify.NewWatcher returned error: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, []string{configDir}, outputDir); err != nil {
+		t.Fatalf("addWatchDirs returned error: %v", err)
+	}
+	excludes := outputDirExcludes(outputDir)
+
+	// Perform the same rename dance rebuild() does: stage, swap old out,
+	// swap the new build in.
+	staging := outputDir + ".staging"
+	old := outputDir + ".old"
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		t.Fatalf("os.MkdirAll returned error: %v", err)
+	}
+	if err := os.Rename(outputDir, old); err != nil {
+		t.Fatalf("os.Rename returned error: %v", err)
+	}
+	if err := os.Rename(staging, outputDir); err != nil {
+		t.Fatalf("os.Rename returned error: %v", err)
+	}
+	os.RemoveAll(old)
+
+	drain := time.After(500 * time.Millisecond)
+draining:
+	for {
+		select {
+		case event := <-watcher.Events:
+			if shouldTriggerRebuild(event, excludes) {
+				t.Errorf("event %v for rebuild's own output should not trigger a rebuild", event)
+			}
+		case <-drain:
+			break draining
+		}
+	}
+
+	// A genuine change to a watched, non-excluded file must still trigger
+	// a rebuild.
+	configFile := filepath.Join(configDir, "config.yml")
+	if err := os.WriteFile(configFile, []byte("template_id: \"0002\"\n"), 0644); err != nil {
+		t.Fatalf("os.WriteFile returned error: %v", err)
+	}
+
+	select {
+	case event := <-watcher.Events:
+		if !shouldTriggerRebuild(event, excludes) {
+			t.Errorf("expected writing %s to trigger a rebuild, got event: %v", configFile, event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a filesystem event for the config file write")
+	}
+}
+
+// chdir switches the process's working directory to dir for the duration
+// of the test and returns a func to restore it; t.Chdir isn't available
+// until Go 1.24.
+func chdir(t *testing.T, dir string) func() {
+	t.Helper()
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd returned error: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("os.Chdir returned error: %v", err)
+	}
+	return func() {
+		if err := os.Chdir(prev); err != nil {
+			t.Fatalf("error restoring working directory: %v", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+)
+
+// embeddedTemplates bundles every template under templates/ into the
+// binary at compile time, so a template that ships with the project still
+// works with no network access.
+//
+//go:embed templates/*
+var embeddedTemplates embed.FS
+
+// resolveTemplateFS finds a template's files in order: an explicit
+// --template-dir local path, the templates embedded in this binary, and
+// finally the GitHub fallback. It returns an fs.FS rooted at the
+// template's own directory (so "pages/base.html" etc. resolve), the OS
+// path backing it when there is one (for the dev server to watch; empty
+// for embedded templates), and a cleanup func to call once the caller is
+// done with it.
+func resolveTemplateFS(templateId, templateDirFlag string) (fs.FS, string, func(), error) {
+	noop := func() {}
+
+	if templateDirFlag != "" {
+		info, err := os.Stat(templateDirFlag)
+		if err != nil || !info.IsDir() {
+			return nil, "", noop, fmt.Errorf("--template-dir %q is not a usable directory: %v", templateDirFlag, err)
+		}
+		return os.DirFS(templateDirFlag), templateDirFlag, noop, nil
+	}
+
+	if sub, err := fs.Sub(embeddedTemplates, path.Join("templates", templateId)); err == nil {
+		if info, err := fs.Stat(sub, "pages"); err == nil && info.IsDir() {
+			log.Printf("Using embedded template '%s'", templateId)
+			return sub, "", noop, nil
+		}
+	}
+
+	templateDir := downloadTemplateFromGitHub(templateId)
+	cleanup := func() { os.RemoveAll(templateDir) }
+	return os.DirFS(templateDir), templateDir, cleanup, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestResolveTemplateFSExplicitDir(t *testing.T) {
+	templateFS, dirPath, cleanup, err := resolveTemplateFS("0002", "templates/0002")
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("resolveTemplateFS returned error: %v", err)
+	}
+	if dirPath != "templates/0002" {
+		t.Errorf("expected dirPath %q, got %q", "templates/0002", dirPath)
+	}
+	if _, err := templateFS.Open("pages/posts.html"); err != nil {
+		t.Errorf("expected pages/posts.html to be reachable: %v", err)
+	}
+}
+
+func TestResolveTemplateFSEmbedded(t *testing.T) {
+	templateFS, dirPath, cleanup, err := resolveTemplateFS("0002", "")
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("resolveTemplateFS returned error: %v", err)
+	}
+	if dirPath != "" {
+		t.Errorf("expected embedded template to report no OS path, got %q", dirPath)
+	}
+	if _, err := templateFS.Open("pages/posts.html"); err != nil {
+		t.Errorf("expected embedded pages/posts.html to be reachable: %v", err)
+	}
+}